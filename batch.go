@@ -0,0 +1,696 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/miekg/dns"
+	"golang.org/x/sync/errgroup"
+)
+
+// The typed record structs below mirror the Lookup* helpers but carry
+// structured fields instead of pre-formatted strings, so --batch/--json
+// output can be consumed programmatically.
+
+type ARecord struct {
+	Address string `json:"address"`
+}
+
+type AAAARecord struct {
+	Address string `json:"address"`
+}
+
+type CNAMERecord struct {
+	Target string `json:"target"`
+}
+
+type MXRecord struct {
+	Host string `json:"host"`
+	Pref uint16 `json:"pref"`
+}
+
+type NSRecord struct {
+	Host string `json:"host"`
+}
+
+type TXTRecord struct {
+	Text string `json:"text"`
+}
+
+type SOARecord struct {
+	Ns      string `json:"ns"`
+	Mbox    string `json:"mbox"`
+	Serial  uint32 `json:"serial"`
+	Refresh uint32 `json:"refresh"`
+	Retry   uint32 `json:"retry"`
+	Expire  uint32 `json:"expire"`
+	Minttl  uint32 `json:"minttl"`
+}
+
+type SRVRecord struct {
+	Target   string `json:"target"`
+	Port     uint16 `json:"port"`
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
+}
+
+type CERTRecord struct {
+	Type        uint16 `json:"type"`
+	KeyTag      uint16 `json:"key_tag"`
+	Algorithm   uint8  `json:"algorithm"`
+	Certificate string `json:"certificate"`
+}
+
+type DNAMERecord struct {
+	Target string `json:"target"`
+}
+
+type PTRRecord struct {
+	Name string `json:"name"`
+}
+
+type CAARecord struct {
+	Flag  uint8  `json:"flag"`
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+}
+
+type TLSARecord struct {
+	Usage        uint8  `json:"usage"`
+	Selector     uint8  `json:"selector"`
+	MatchingType uint8  `json:"matching_type"`
+	Certificate  string `json:"certificate"`
+}
+
+type SSHFPRecord struct {
+	Algorithm   uint8  `json:"algorithm"`
+	Type        uint8  `json:"type"`
+	FingerPrint string `json:"fingerprint"`
+}
+
+type NAPTRRecord struct {
+	Order       uint16 `json:"order"`
+	Preference  uint16 `json:"preference"`
+	Flags       string `json:"flags"`
+	Service     string `json:"service"`
+	Regexp      string `json:"regexp"`
+	Replacement string `json:"replacement"`
+}
+
+type HTTPSRecord struct {
+	Priority uint16   `json:"priority"`
+	Target   string   `json:"target"`
+	Params   []string `json:"params,omitempty"`
+}
+
+type SVCBRecord struct {
+	Priority uint16   `json:"priority"`
+	Target   string   `json:"target"`
+	Params   []string `json:"params,omitempty"`
+}
+
+type DNSKEYRecord struct {
+	Flags     uint16 `json:"flags"`
+	Protocol  uint8  `json:"protocol"`
+	Algorithm uint8  `json:"algorithm"`
+	KeyTag    uint16 `json:"key_tag"`
+}
+
+type DSRecord struct {
+	KeyTag     uint16 `json:"key_tag"`
+	Algorithm  uint8  `json:"algorithm"`
+	DigestType uint8  `json:"digest_type"`
+	Digest     string `json:"digest"`
+}
+
+// TargetResult is the structured, per-target result emitted by --batch
+// (one line of newline-delimited JSON per input) and --json (single
+// target) modes.
+type TargetResult struct {
+	Target string            `json:"target"`
+	A      []ARecord         `json:"a,omitempty"`
+	AAAA   []AAAARecord      `json:"aaaa,omitempty"`
+	CNAME  []CNAMERecord     `json:"cname,omitempty"`
+	MX     []MXRecord        `json:"mx,omitempty"`
+	NS     []NSRecord        `json:"ns,omitempty"`
+	TXT    []TXTRecord       `json:"txt,omitempty"`
+	SOA    []SOARecord       `json:"soa,omitempty"`
+	SRV    []SRVRecord       `json:"srv,omitempty"`
+	CERT   []CERTRecord      `json:"cert,omitempty"`
+	DNAME  []DNAMERecord     `json:"dname,omitempty"`
+	CAA    []CAARecord       `json:"caa,omitempty"`
+	TLSA   []TLSARecord      `json:"tlsa,omitempty"`
+	SSHFP  []SSHFPRecord     `json:"sshfp,omitempty"`
+	NAPTR  []NAPTRRecord     `json:"naptr,omitempty"`
+	HTTPS  []HTTPSRecord     `json:"https,omitempty"`
+	SVCB   []SVCBRecord      `json:"svcb,omitempty"`
+	DNSKEY []DNSKEYRecord    `json:"dnskey,omitempty"`
+	DS     []DSRecord        `json:"ds,omitempty"`
+	PTR    []PTRRecord       `json:"ptr,omitempty"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+func lookupATyped(ctx context.Context, domain string) ([]ARecord, error) {
+	addrs, err := lookupA(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ARecord, len(addrs))
+	for i, a := range addrs {
+		result[i] = ARecord{Address: a}
+	}
+	return result, nil
+}
+
+func lookupAAAATyped(ctx context.Context, domain string) ([]AAAARecord, error) {
+	addrs, err := lookupAAAA(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]AAAARecord, len(addrs))
+	for i, a := range addrs {
+		result[i] = AAAARecord{Address: a}
+	}
+	return result, nil
+}
+
+func lookupCNAMETyped(ctx context.Context, domain string) ([]CNAMERecord, error) {
+	cname, err := net.DefaultResolver.LookupCNAME(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	return []CNAMERecord{{Target: cname}}, nil
+}
+
+func lookupMXTyped(ctx context.Context, domain string) ([]MXRecord, error) {
+	mxs, err := net.DefaultResolver.LookupMX(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]MXRecord, len(mxs))
+	for i, mx := range mxs {
+		result[i] = MXRecord{Host: mx.Host, Pref: mx.Pref}
+	}
+	return result, nil
+}
+
+func lookupNSTyped(ctx context.Context, domain string) ([]NSRecord, error) {
+	nss, err := net.DefaultResolver.LookupNS(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]NSRecord, len(nss))
+	for i, ns := range nss {
+		result[i] = NSRecord{Host: ns.Host}
+	}
+	return result, nil
+}
+
+func lookupTXTTyped(ctx context.Context, domain string) ([]TXTRecord, error) {
+	txts, err := net.DefaultResolver.LookupTXT(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]TXTRecord, len(txts))
+	for i, t := range txts {
+		result[i] = TXTRecord{Text: t}
+	}
+	return result, nil
+}
+
+func lookupSOATyped(ctx context.Context, domain string) ([]SOARecord, error) {
+	rrs, err := dnsQuery(ctx, domain, "SOA")
+	if err != nil {
+		return nil, err
+	}
+	var result []SOARecord
+	for _, rr := range rrs {
+		if soa, ok := rr.(*dns.SOA); ok {
+			result = append(result, SOARecord{
+				Ns:      soa.Ns,
+				Mbox:    soa.Mbox,
+				Serial:  soa.Serial,
+				Refresh: soa.Refresh,
+				Retry:   soa.Retry,
+				Expire:  soa.Expire,
+				Minttl:  soa.Minttl,
+			})
+		}
+	}
+	return result, nil
+}
+
+func lookupSRVTyped(ctx context.Context, domain string) ([]SRVRecord, error) {
+	_, srvs, err := lookupSRVFull(ctx, "", "", domain)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]SRVRecord, len(srvs))
+	for i, srv := range srvs {
+		result[i] = SRVRecord{Target: srv.Target, Port: srv.Port, Priority: srv.Priority, Weight: srv.Weight}
+	}
+	return result, nil
+}
+
+func lookupCERTTyped(ctx context.Context, domain string) ([]CERTRecord, error) {
+	rrs, err := dnsQuery(ctx, domain, "CERT")
+	if err != nil {
+		return nil, err
+	}
+	var result []CERTRecord
+	for _, rr := range rrs {
+		if cert, ok := rr.(*dns.CERT); ok {
+			result = append(result, CERTRecord{
+				Type:        cert.Type,
+				KeyTag:      cert.KeyTag,
+				Algorithm:   cert.Algorithm,
+				Certificate: fmt.Sprintf("%x", cert.Certificate),
+			})
+		}
+	}
+	return result, nil
+}
+
+func lookupDNAMETyped(ctx context.Context, domain string) ([]DNAMERecord, error) {
+	rrs, err := dnsQuery(ctx, domain, "DNAME")
+	if err != nil {
+		return nil, err
+	}
+	var result []DNAMERecord
+	for _, rr := range rrs {
+		if dname, ok := rr.(*dns.DNAME); ok {
+			result = append(result, DNAMERecord{Target: dname.Target})
+		}
+	}
+	return result, nil
+}
+
+func lookupCAATyped(ctx context.Context, domain string) ([]CAARecord, error) {
+	rrs, err := dnsQuery(ctx, domain, "CAA")
+	if err != nil {
+		return nil, err
+	}
+	var result []CAARecord
+	for _, rr := range rrs {
+		if caa, ok := rr.(*dns.CAA); ok {
+			result = append(result, CAARecord{Flag: caa.Flag, Tag: caa.Tag, Value: caa.Value})
+		}
+	}
+	return result, nil
+}
+
+func lookupTLSATyped(ctx context.Context, domain string) ([]TLSARecord, error) {
+	rrs, err := dnsQuery(ctx, domain, "TLSA")
+	if err != nil {
+		return nil, err
+	}
+	var result []TLSARecord
+	for _, rr := range rrs {
+		if tlsa, ok := rr.(*dns.TLSA); ok {
+			result = append(result, TLSARecord{
+				Usage:        tlsa.Usage,
+				Selector:     tlsa.Selector,
+				MatchingType: tlsa.MatchingType,
+				Certificate:  tlsa.Certificate,
+			})
+		}
+	}
+	return result, nil
+}
+
+func lookupSSHFPTyped(ctx context.Context, domain string) ([]SSHFPRecord, error) {
+	rrs, err := dnsQuery(ctx, domain, "SSHFP")
+	if err != nil {
+		return nil, err
+	}
+	var result []SSHFPRecord
+	for _, rr := range rrs {
+		if sshfp, ok := rr.(*dns.SSHFP); ok {
+			result = append(result, SSHFPRecord{
+				Algorithm:   sshfp.Algorithm,
+				Type:        sshfp.Type,
+				FingerPrint: sshfp.FingerPrint,
+			})
+		}
+	}
+	return result, nil
+}
+
+func lookupNAPTRTyped(ctx context.Context, domain string) ([]NAPTRRecord, error) {
+	rrs, err := dnsQuery(ctx, domain, "NAPTR")
+	if err != nil {
+		return nil, err
+	}
+	var result []NAPTRRecord
+	for _, rr := range rrs {
+		if naptr, ok := rr.(*dns.NAPTR); ok {
+			result = append(result, NAPTRRecord{
+				Order:       naptr.Order,
+				Preference:  naptr.Preference,
+				Flags:       naptr.Flags,
+				Service:     naptr.Service,
+				Regexp:      naptr.Regexp,
+				Replacement: naptr.Replacement,
+			})
+		}
+	}
+	return result, nil
+}
+
+func svcParams(values []dns.SVCBKeyValue) []string {
+	params := make([]string, len(values))
+	for i, v := range values {
+		params[i] = v.String()
+	}
+	return params
+}
+
+func lookupHTTPSTyped(ctx context.Context, domain string) ([]HTTPSRecord, error) {
+	rrs, err := dnsQuery(ctx, domain, "HTTPS")
+	if err != nil {
+		return nil, err
+	}
+	var result []HTTPSRecord
+	for _, rr := range rrs {
+		if https, ok := rr.(*dns.HTTPS); ok {
+			result = append(result, HTTPSRecord{
+				Priority: https.Priority,
+				Target:   https.Target,
+				Params:   svcParams(https.Value),
+			})
+		}
+	}
+	return result, nil
+}
+
+func lookupSVCBTyped(ctx context.Context, domain string) ([]SVCBRecord, error) {
+	rrs, err := dnsQuery(ctx, domain, "SVCB")
+	if err != nil {
+		return nil, err
+	}
+	var result []SVCBRecord
+	for _, rr := range rrs {
+		if svcb, ok := rr.(*dns.SVCB); ok {
+			result = append(result, SVCBRecord{
+				Priority: svcb.Priority,
+				Target:   svcb.Target,
+				Params:   svcParams(svcb.Value),
+			})
+		}
+	}
+	return result, nil
+}
+
+func lookupDNSKEYTyped(ctx context.Context, domain string) ([]DNSKEYRecord, error) {
+	rrs, err := dnsQuery(ctx, domain, "DNSKEY")
+	if err != nil {
+		return nil, err
+	}
+	var result []DNSKEYRecord
+	for _, rr := range rrs {
+		if key, ok := rr.(*dns.DNSKEY); ok {
+			result = append(result, DNSKEYRecord{
+				Flags:     key.Flags,
+				Protocol:  key.Protocol,
+				Algorithm: key.Algorithm,
+				KeyTag:    key.KeyTag(),
+			})
+		}
+	}
+	return result, nil
+}
+
+func lookupDSTyped(ctx context.Context, domain string) ([]DSRecord, error) {
+	rrs, err := dnsQuery(ctx, domain, "DS")
+	if err != nil {
+		return nil, err
+	}
+	var result []DSRecord
+	for _, rr := range rrs {
+		if ds, ok := rr.(*dns.DS); ok {
+			result = append(result, DSRecord{
+				KeyTag:     ds.KeyTag,
+				Algorithm:  ds.Algorithm,
+				DigestType: ds.DigestType,
+				Digest:     ds.Digest,
+			})
+		}
+	}
+	return result, nil
+}
+
+func lookupPTRTyped(ctx context.Context, addr string) ([]PTRRecord, error) {
+	names, err := lookupPTR(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]PTRRecord, len(names))
+	for i, n := range names {
+		result[i] = PTRRecord{Name: n}
+	}
+	return result, nil
+}
+
+// resolveTarget runs every applicable typed lookup for target concurrently,
+// capped at parallel lookups at once, and assembles a TargetResult,
+// recording per-record-type failures in Errors rather than aborting the
+// whole target.
+func resolveTarget(ctx context.Context, target string, parallel int) TargetResult {
+	result := TargetResult{Target: target, Errors: map[string]string{}}
+
+	if net.ParseIP(target) != nil {
+		ptrs, err := lookupPTRTyped(ctx, target)
+		if err != nil {
+			result.Errors["PTR"] = err.Error()
+		} else {
+			result.PTR = ptrs
+		}
+		return result
+	}
+
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(parallel)
+
+	run := func(typeName string, fn func() error) {
+		g.Go(func() error {
+			if err := fn(); err != nil {
+				mu.Lock()
+				result.Errors[typeName] = err.Error()
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+
+	run("A", func() error {
+		rs, err := lookupATyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.A = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("AAAA", func() error {
+		rs, err := lookupAAAATyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.AAAA = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("CNAME", func() error {
+		rs, err := lookupCNAMETyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.CNAME = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("MX", func() error {
+		rs, err := lookupMXTyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.MX = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("NS", func() error {
+		rs, err := lookupNSTyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.NS = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("TXT", func() error {
+		rs, err := lookupTXTTyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.TXT = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("SOA", func() error {
+		rs, err := lookupSOATyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.SOA = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("SRV", func() error {
+		rs, err := lookupSRVTyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.SRV = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("CERT", func() error {
+		rs, err := lookupCERTTyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.CERT = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("DNAME", func() error {
+		rs, err := lookupDNAMETyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.DNAME = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("CAA", func() error {
+		rs, err := lookupCAATyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.CAA = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("TLSA", func() error {
+		rs, err := lookupTLSATyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.TLSA = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("SSHFP", func() error {
+		rs, err := lookupSSHFPTyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.SSHFP = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("NAPTR", func() error {
+		rs, err := lookupNAPTRTyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.NAPTR = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("HTTPS", func() error {
+		rs, err := lookupHTTPSTyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.HTTPS = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("SVCB", func() error {
+		rs, err := lookupSVCBTyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.SVCB = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("DNSKEY", func() error {
+		rs, err := lookupDNSKEYTyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.DNSKEY = rs
+			mu.Unlock()
+		}
+		return err
+	})
+	run("DS", func() error {
+		rs, err := lookupDSTyped(gctx, target)
+		if err == nil {
+			mu.Lock()
+			result.DS = rs
+			mu.Unlock()
+		}
+		return err
+	})
+
+	g.Wait()
+	if len(result.Errors) == 0 {
+		result.Errors = nil
+	}
+	return result
+}
+
+// runBatch reads one domain or IP per line from r and writes one
+// newline-delimited JSON TargetResult per line to w, resolving up to
+// parallel targets concurrently.
+func runBatch(ctx context.Context, r io.Reader, w io.Writer, parallel int) error {
+	enc := json.NewEncoder(w)
+	var encMu sync.Mutex
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(parallel)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		target := scanner.Text()
+		if target == "" {
+			continue
+		}
+		g.Go(func() error {
+			result := resolveTarget(gctx, target, parallel)
+			encMu.Lock()
+			defer encMu.Unlock()
+			return enc.Encode(result)
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return g.Wait()
+}
+
+// runJSON resolves a single target and writes it as one JSON object to w.
+func runJSON(ctx context.Context, target string, w io.Writer, parallel int) error {
+	result := resolveTarget(ctx, target, parallel)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
@@ -1,26 +1,96 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"net"
 	"os"
 
 	"github.com/miekg/dns"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
 )
 
 func main() {
-	if len(os.Args) != 2 {
-		fmt.Fprintf(os.Stderr, "Usage: godns <domain|ip>\n")
-		os.Exit(1)
+	if len(os.Args) >= 2 && os.Args[1] == "srv" {
+		runSRVCommand(os.Args[2:])
+		return
+	}
+
+	fs := flag.NewFlagSet("godns", flag.ExitOnError)
+	rf := addResolverFlags(fs)
+	dnssec := fs.Bool("dnssec", false, "request DNSSEC signatures and print per-RRset validation status")
+	parallel := fs.Int("parallel", 10, "maximum number of record types to query concurrently")
+	batch := fs.Bool("batch", false, "read one domain or IP per line from stdin, emit newline-delimited JSON results")
+	jsonOut := fs.Bool("json", false, "emit a single target's result as JSON instead of plain text")
+	trace := fs.Bool("trace", false, "resolve iteratively from the root servers instead of using a recursive resolver")
+	reverseCIDR := fs.String("reverse-cidr", "", "walk a small IPv4 CIDR (<= /24), printing a PTR table for the subnet")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: godns [--dnssec] [--server host[,host...]] [--tls] [--doh-url url] [--timeout d] [--parallel n] [--json] [--trace] <domain|ip>\n")
+		fmt.Fprintf(os.Stderr, "       godns --batch [--timeout d] [--parallel n] < targets.txt\n")
+		fmt.Fprintf(os.Stderr, "       godns --reverse-cidr <cidr>\n")
+		fmt.Fprintf(os.Stderr, "       godns srv <service> <proto> <name>\n")
+	}
+	fs.Parse(os.Args[1:])
+	switch {
+	case *batch, *reverseCIDR != "":
+		if fs.NArg() != 0 {
+			fs.Usage()
+			os.Exit(1)
+		}
+	default:
+		if fs.NArg() != 1 {
+			fs.Usage()
+			os.Exit(1)
+		}
+	}
+	var target string
+	if !*batch && *reverseCIDR == "" {
+		target = fs.Arg(0)
+	}
+
+	rf.apply()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *rf.timeout)
+	defer cancel()
+
+	if *reverseCIDR != "" {
+		if err := runReverseCIDR(ctx, *reverseCIDR); err != nil {
+			fmt.Fprintf(os.Stderr, "reverse-cidr: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *batch {
+		if err := runBatch(ctx, os.Stdin, os.Stdout, *parallel); err != nil {
+			fmt.Fprintf(os.Stderr, "batch: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *jsonOut {
+		if err := runJSON(ctx, target, os.Stdout, *parallel); err != nil {
+			fmt.Fprintf(os.Stderr, "json: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
-	target := os.Args[1]
+
+	if *trace {
+		runTrace(ctx, target)
+		return
+	}
+
 	isIP := net.ParseIP(target) != nil
 
 	fmt.Printf("DNS records for %s:\n\n", target)
 
 	if isIP {
 		// Only PTR for IP addresses
-		records, err := lookupPTR(target)
+		records, err := lookupPTR(ctx, target)
 		if err != nil {
 			fmt.Printf("PTR: error: %v\n", err)
 		} else if len(records) > 0 {
@@ -34,7 +104,7 @@ func main() {
 
 	lookupFuncs := []struct {
 		typeName string
-		lookup  func(string) ([]string, error)
+		lookup   func(context.Context, string) ([]string, error)
 	}{
 		{"A", lookupA},
 		{"AAAA", lookupAAAA},
@@ -46,25 +116,61 @@ func main() {
 		{"SRV", lookupSRV},
 		{"CERT", lookupCERT},
 		{"DNAME", lookupDNAME},
+		{"CAA", lookupCAA},
+		{"TLSA", lookupTLSA},
+		{"SSHFP", lookupSSHFP},
+		{"NAPTR", lookupNAPTR},
+		{"HTTPS", lookupHTTPS},
+		{"SVCB", lookupSVCB},
+		{"DNSKEY", lookupDNSKEYRecords},
+		{"DS", lookupDS},
 	}
 
-	for _, lf := range lookupFuncs {
-		records, err := lf.lookup(target)
-		if err != nil {
-			fmt.Printf("%s: error: %v\n", lf.typeName, err)
+	type lookupResult struct {
+		records []string
+		err     error
+	}
+	results := make([]lookupResult, len(lookupFuncs))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(*parallel)
+	for i, lf := range lookupFuncs {
+		i, lf := i, lf
+		g.Go(func() error {
+			records, err := lf.lookup(gctx, target)
+			results[i] = lookupResult{records: records, err: err}
+			return nil
+		})
+	}
+	g.Wait()
+
+	// Results are printed in the same stable, table order every run,
+	// even though the underlying queries ran concurrently.
+	for i, lf := range lookupFuncs {
+		res := results[i]
+		if res.err != nil {
+			fmt.Printf("%s: error: %v\n", lf.typeName, res.err)
 			continue
 		}
-		if len(records) > 0 {
+		if len(res.records) > 0 {
 			fmt.Printf("%s:\n", lf.typeName)
-			for _, rec := range records {
+			for _, rec := range res.records {
 				fmt.Printf("  %s\n", rec)
 			}
+			if *dnssec {
+				status, err := dnssecStatus(ctx, target, lf.typeName)
+				if err != nil {
+					fmt.Printf("  dnssec: %s (%v)\n", status, err)
+				} else {
+					fmt.Printf("  dnssec: %s\n", status)
+				}
+			}
 		}
 	}
 }
 
-func lookupA(domain string) ([]string, error) {
-	addrs, err := net.LookupHost(domain)
+func lookupA(ctx context.Context, domain string) ([]string, error) {
+	addrs, err := net.DefaultResolver.LookupHost(ctx, domain)
 	if err != nil {
 		return nil, err
 	}
@@ -77,8 +183,8 @@ func lookupA(domain string) ([]string, error) {
 	return result, nil
 }
 
-func lookupAAAA(domain string) ([]string, error) {
-	addrs, err := net.LookupHost(domain)
+func lookupAAAA(ctx context.Context, domain string) ([]string, error) {
+	addrs, err := net.DefaultResolver.LookupHost(ctx, domain)
 	if err != nil {
 		return nil, err
 	}
@@ -91,16 +197,16 @@ func lookupAAAA(domain string) ([]string, error) {
 	return result, nil
 }
 
-func lookupCNAME(domain string) ([]string, error) {
-	cname, err := net.LookupCNAME(domain)
+func lookupCNAME(ctx context.Context, domain string) ([]string, error) {
+	cname, err := net.DefaultResolver.LookupCNAME(ctx, domain)
 	if err != nil {
 		return nil, err
 	}
 	return []string{cname}, nil
 }
 
-func lookupMX(domain string) ([]string, error) {
-	mxs, err := net.LookupMX(domain)
+func lookupMX(ctx context.Context, domain string) ([]string, error) {
+	mxs, err := net.DefaultResolver.LookupMX(ctx, domain)
 	if err != nil {
 		return nil, err
 	}
@@ -111,8 +217,8 @@ func lookupMX(domain string) ([]string, error) {
 	return result, nil
 }
 
-func lookupNS(domain string) ([]string, error) {
-	nss, err := net.LookupNS(domain)
+func lookupNS(ctx context.Context, domain string) ([]string, error) {
+	nss, err := net.DefaultResolver.LookupNS(ctx, domain)
 	if err != nil {
 		return nil, err
 	}
@@ -123,72 +229,80 @@ func lookupNS(domain string) ([]string, error) {
 	return result, nil
 }
 
-func lookupTXT(domain string) ([]string, error) {
-	txts, err := net.LookupTXT(domain)
+func lookupTXT(ctx context.Context, domain string) ([]string, error) {
+	txts, err := net.DefaultResolver.LookupTXT(ctx, domain)
 	if err != nil {
 		return nil, err
 	}
 	return txts, nil
 }
 
-// Helper for miekg/dns lookups
-func dnsQuery(domain, qtype string) ([]dns.RR, error) {
-	c := new(dns.Client)
-	m := new(dns.Msg)
-	m.SetQuestion(dns.Fqdn(domain), dns.StringToType[qtype])
-	resp, _, err := c.Exchange(m, "8.8.8.8:53")
-	if err != nil {
-		return nil, err
-	}
-	if resp.Rcode != dns.RcodeSuccess {
-		return nil, fmt.Errorf("invalid answer for %s type %s", domain, qtype)
-	}
-	return resp.Answer, nil
-}
+// dnsQueryGroup dedupes identical concurrent (qtype, name) queries, which
+// matters once batch mode fans out many targets that share e.g. the same
+// NS lookups.
+var dnsQueryGroup singleflight.Group
 
-func lookupSOA(domain string) ([]string, error) {
-	rrs, err := dnsQuery(domain, "SOA")
-	if err != nil {
-		return nil, err
-	}
-	var result []string
-	for _, rr := range rrs {
-		if soa, ok := rr.(*dns.SOA); ok {
-			result = append(result, fmt.Sprintf("NS: %s, Mbox: %s, Serial: %d", soa.Ns, soa.Mbox, soa.Serial))
+// dnsQueryFunc is the shape of dnsQuery. Every lookup* helper built on
+// dns.RR goes through the dnsQuery package variable rather than calling a
+// hard-coded function, so tests can swap it for a fake that never touches
+// the network.
+type dnsQueryFunc func(ctx context.Context, domain, qtype string) ([]dns.RR, error)
+
+// dnsQuery is the helper every miekg/dns-based lookup* function calls.
+// Queries are sent through globalResolver so they benefit from whatever
+// servers/transport the user configured via --server/--tls/--doh-url.
+var dnsQuery dnsQueryFunc = queryRRs
+
+func queryRRs(ctx context.Context, domain, qtype string) ([]dns.RR, error) {
+	var lastErr error
+	for _, candidate := range globalResolver.qualify(domain) {
+		rrs, err := queryOneName(ctx, candidate, qtype)
+		if err == nil {
+			return rrs, nil
 		}
+		lastErr = err
 	}
-	return result, nil
+	return nil, lastErr
 }
 
-func lookupPTR(domain string) ([]string, error) {
-	// For PTR, domain should be an IP address
-	addr := domain
-	if net.ParseIP(addr) == nil {
-		return nil, fmt.Errorf("PTR lookup requires an IP address")
-	}
-	ptr, err := net.LookupAddr(addr)
+// queryOneName issues (and dedupes) a single wire-format query for an
+// already-qualified name.
+func queryOneName(ctx context.Context, fqdn, qtype string) ([]dns.RR, error) {
+	key := qtype + "/" + fqdn
+	v, err, _ := dnsQueryGroup.Do(key, func() (interface{}, error) {
+		m := new(dns.Msg)
+		m.SetQuestion(fqdn, dns.StringToType[qtype])
+		resp, err := globalResolver.Exchange(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Rcode != dns.RcodeSuccess {
+			return nil, fmt.Errorf("invalid answer for %s type %s", fqdn, qtype)
+		}
+		return resp.Answer, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return ptr, nil
+	return v.([]dns.RR), nil
 }
 
-func lookupSRV(domain string) ([]string, error) {
-	rrs, err := dnsQuery(domain, "SRV")
+func lookupSOA(ctx context.Context, domain string) ([]string, error) {
+	rrs, err := dnsQuery(ctx, domain, "SOA")
 	if err != nil {
 		return nil, err
 	}
 	var result []string
 	for _, rr := range rrs {
-		if srv, ok := rr.(*dns.SRV); ok {
-			result = append(result, fmt.Sprintf("%s %d %d %d", srv.Target, srv.Port, srv.Priority, srv.Weight))
+		if soa, ok := rr.(*dns.SOA); ok {
+			result = append(result, fmt.Sprintf("NS: %s, Mbox: %s, Serial: %d", soa.Ns, soa.Mbox, soa.Serial))
 		}
 	}
 	return result, nil
 }
 
-func lookupCERT(domain string) ([]string, error) {
-	rrs, err := dnsQuery(domain, "CERT")
+func lookupCERT(ctx context.Context, domain string) ([]string, error) {
+	rrs, err := dnsQuery(ctx, domain, "CERT")
 	if err != nil {
 		return nil, err
 	}
@@ -201,8 +315,17 @@ func lookupCERT(domain string) ([]string, error) {
 	return result, nil
 }
 
-func lookupDNAME(domain string) ([]string, error) {
-	rrs, err := dnsQuery(domain, "DNAME")
+// withDefaultPort appends defaultPort to addr if it doesn't already
+// specify one.
+func withDefaultPort(addr, defaultPort string) string {
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr
+	}
+	return net.JoinHostPort(addr, defaultPort)
+}
+
+func lookupDNAME(ctx context.Context, domain string) ([]string, error) {
+	rrs, err := dnsQuery(ctx, domain, "DNAME")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxReverseCIDRBits caps --reverse-cidr to at most a /24 (256 addresses),
+// so a typo doesn't fan out thousands of PTR queries.
+const maxReverseCIDRBits = 8
+
+// reverseName builds the explicit reverse-DNS query name for ip: the
+// dotted in-addr.arpa form for IPv4, the nibble-reversed ip6.arpa form for
+// IPv6. Building this ourselves (rather than delegating to net.LookupAddr)
+// avoids the platform inconsistency in whether Go's resolver returns
+// rooted or unrooted names.
+func reverseName(ip net.IP) (string, error) {
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", v4[3], v4[2], v4[1], v4[0]), nil
+	}
+	v6 := ip.To16()
+	if v6 == nil {
+		return "", fmt.Errorf("invalid IP address %q", ip)
+	}
+	var sb strings.Builder
+	for i := len(v6) - 1; i >= 0; i-- {
+		fmt.Fprintf(&sb, "%x.%x.", v6[i]&0x0f, v6[i]>>4)
+	}
+	sb.WriteString("ip6.arpa.")
+	return sb.String(), nil
+}
+
+// lookupPTR issues an explicit PTR query for domain (which must be an IP
+// address) and always returns fully-qualified, rooted names.
+func lookupPTR(ctx context.Context, domain string) ([]string, error) {
+	ip := net.ParseIP(domain)
+	if ip == nil {
+		return nil, fmt.Errorf("PTR lookup requires an IP address")
+	}
+	name, err := reverseName(ip)
+	if err != nil {
+		return nil, err
+	}
+	rrs, err := dnsQuery(ctx, name, "PTR")
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, rr := range rrs {
+		if ptr, ok := rr.(*dns.PTR); ok {
+			result = append(result, dns.Fqdn(ptr.Ptr))
+		}
+	}
+	return result, nil
+}
+
+// incIP increments ip in place, treating it as a big-endian counter.
+func incIP(ip net.IP) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			return
+		}
+	}
+}
+
+// runReverseCIDR walks every address in cidr (capped at maxReverseCIDRBits
+// host bits), issuing PTR queries concurrently and printing an
+// address -> name table, useful for auditing a subnet.
+func runReverseCIDR(ctx context.Context, cidr string) error {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return fmt.Errorf("parsing %q: %w", cidr, err)
+	}
+	ones, bits := ipnet.Mask.Size()
+	if bits != 32 {
+		return fmt.Errorf("--reverse-cidr only supports IPv4 prefixes")
+	}
+	if bits-ones > maxReverseCIDRBits {
+		return fmt.Errorf("--reverse-cidr prefix must be /%d or smaller (got /%d)", 32-maxReverseCIDRBits, ones)
+	}
+
+	var addrs []net.IP
+	for ip := ipnet.IP.Mask(ipnet.Mask); ipnet.Contains(ip); incIP(ip) {
+		addrs = append(addrs, append(net.IP(nil), ip...))
+	}
+
+	type row struct {
+		addr  string
+		names []string
+		err   error
+	}
+	rows := make([]row, len(addrs))
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, ip := range addrs {
+		i, addr := i, ip.String()
+		g.Go(func() error {
+			names, err := lookupPTR(gctx, addr)
+			rows[i] = row{addr: addr, names: names, err: err}
+			return nil
+		})
+	}
+	g.Wait()
+
+	for _, r := range rows {
+		switch {
+		case r.err != nil:
+			fmt.Printf("%-15s  error: %v\n", r.addr, r.err)
+		case len(r.names) > 0:
+			fmt.Printf("%-15s  %s\n", r.addr, strings.Join(r.names, ", "))
+		default:
+			fmt.Printf("%-15s  (no PTR)\n", r.addr)
+		}
+	}
+	return nil
+}
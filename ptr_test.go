@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestReverseName(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"192.0.2.1", "1.2.0.192.in-addr.arpa."},
+		{"2001:db8::1", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			got, err := reverseName(net.ParseIP(tt.ip))
+			if err != nil {
+				t.Fatalf("reverseName(%q): %v", tt.ip, err)
+			}
+			if got != tt.want {
+				t.Fatalf("reverseName(%q) = %q, want %q", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReverseNameInvalid(t *testing.T) {
+	if _, err := reverseName(nil); err == nil {
+		t.Fatal("reverseName(nil): expected error, got nil")
+	}
+}
+
+func TestIncIP(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"192.0.2.1", "192.0.2.2"},
+		{"192.0.2.255", "192.0.3.0"},
+		{"255.255.255.255", "0.0.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			ip := net.ParseIP(tt.in).To4()
+			incIP(ip)
+			if ip.String() != tt.want {
+				t.Fatalf("incIP(%q) = %q, want %q", tt.in, ip.String(), tt.want)
+			}
+		})
+	}
+}
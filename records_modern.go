@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+func lookupCAA(ctx context.Context, domain string) ([]string, error) {
+	rrs, err := dnsQuery(ctx, domain, "CAA")
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, rr := range rrs {
+		if caa, ok := rr.(*dns.CAA); ok {
+			result = append(result, fmt.Sprintf("%d %s %q", caa.Flag, caa.Tag, caa.Value))
+		}
+	}
+	return result, nil
+}
+
+func lookupTLSA(ctx context.Context, domain string) ([]string, error) {
+	rrs, err := dnsQuery(ctx, domain, "TLSA")
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, rr := range rrs {
+		if tlsa, ok := rr.(*dns.TLSA); ok {
+			result = append(result, fmt.Sprintf("usage=%d selector=%d matching-type=%d cert=%s",
+				tlsa.Usage, tlsa.Selector, tlsa.MatchingType, tlsa.Certificate))
+		}
+	}
+	return result, nil
+}
+
+func lookupSSHFP(ctx context.Context, domain string) ([]string, error) {
+	rrs, err := dnsQuery(ctx, domain, "SSHFP")
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, rr := range rrs {
+		if sshfp, ok := rr.(*dns.SSHFP); ok {
+			result = append(result, fmt.Sprintf("algorithm=%d type=%d fingerprint=%s",
+				sshfp.Algorithm, sshfp.Type, sshfp.FingerPrint))
+		}
+	}
+	return result, nil
+}
+
+func lookupNAPTR(ctx context.Context, domain string) ([]string, error) {
+	rrs, err := dnsQuery(ctx, domain, "NAPTR")
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, rr := range rrs {
+		if naptr, ok := rr.(*dns.NAPTR); ok {
+			result = append(result, fmt.Sprintf("order=%d pref=%d flags=%q service=%q regexp=%q replacement=%s",
+				naptr.Order, naptr.Preference, naptr.Flags, naptr.Service, naptr.Regexp, naptr.Replacement))
+		}
+	}
+	return result, nil
+}
+
+// svcRecordString renders an HTTPS/SVCB RR's priority, target and
+// SvcParams (alpn, ipv4hint, ipv6hint, ech, ...) in human-readable form;
+// dns.SVCBKeyValue.String() already decodes each known key.
+func svcRecordString(priority uint16, target string, values []dns.SVCBKeyValue) string {
+	params := make([]string, len(values))
+	for i, v := range values {
+		params[i] = v.String()
+	}
+	return fmt.Sprintf("priority=%d target=%s %s", priority, target, strings.Join(params, " "))
+}
+
+func lookupHTTPS(ctx context.Context, domain string) ([]string, error) {
+	rrs, err := dnsQuery(ctx, domain, "HTTPS")
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, rr := range rrs {
+		if https, ok := rr.(*dns.HTTPS); ok {
+			result = append(result, svcRecordString(https.Priority, https.Target, https.Value))
+		}
+	}
+	return result, nil
+}
+
+func lookupSVCB(ctx context.Context, domain string) ([]string, error) {
+	rrs, err := dnsQuery(ctx, domain, "SVCB")
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, rr := range rrs {
+		if svcb, ok := rr.(*dns.SVCB); ok {
+			result = append(result, svcRecordString(svcb.Priority, svcb.Target, svcb.Value))
+		}
+	}
+	return result, nil
+}
+
+func lookupDNSKEYRecords(ctx context.Context, domain string) ([]string, error) {
+	rrs, err := dnsQuery(ctx, domain, "DNSKEY")
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, rr := range rrs {
+		if key, ok := rr.(*dns.DNSKEY); ok {
+			result = append(result, fmt.Sprintf("flags=%d protocol=%d algorithm=%d key-tag=%d",
+				key.Flags, key.Protocol, key.Algorithm, key.KeyTag()))
+		}
+	}
+	return result, nil
+}
+
+func lookupDS(ctx context.Context, domain string) ([]string, error) {
+	rrs, err := dnsQuery(ctx, domain, "DS")
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, rr := range rrs {
+		if ds, ok := rr.(*dns.DS); ok {
+			result = append(result, fmt.Sprintf("key-tag=%d algorithm=%d digest-type=%d digest=%s",
+				ds.KeyTag, ds.Algorithm, ds.DigestType, ds.Digest))
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// withFakeDNSQuery swaps the package-level dnsQuery for fn for the
+// duration of a test, restoring the original afterwards so other tests
+// (and a real run) never hit the network.
+func withFakeDNSQuery(t *testing.T, fn dnsQueryFunc) {
+	t.Helper()
+	orig := dnsQuery
+	dnsQuery = fn
+	t.Cleanup(func() { dnsQuery = orig })
+}
+
+func TestLookupCAA(t *testing.T) {
+	withFakeDNSQuery(t, func(ctx context.Context, domain, qtype string) ([]dns.RR, error) {
+		if qtype != "CAA" {
+			t.Fatalf("qtype = %q, want CAA", qtype)
+		}
+		return []dns.RR{&dns.CAA{
+			Hdr:   dns.RR_Header{Name: domain, Rrtype: dns.TypeCAA},
+			Flag:  0,
+			Tag:   "issue",
+			Value: "letsencrypt.org",
+		}}, nil
+	})
+
+	got, err := lookupCAA(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("lookupCAA: %v", err)
+	}
+	want := `0 issue "letsencrypt.org"`
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("lookupCAA = %v, want [%q]", got, want)
+	}
+}
+
+func TestLookupTLSA(t *testing.T) {
+	withFakeDNSQuery(t, func(ctx context.Context, domain, qtype string) ([]dns.RR, error) {
+		return []dns.RR{&dns.TLSA{
+			Hdr:          dns.RR_Header{Name: domain, Rrtype: dns.TypeTLSA},
+			Usage:        3,
+			Selector:     1,
+			MatchingType: 1,
+			Certificate:  "abcd",
+		}}, nil
+	})
+
+	got, err := lookupTLSA(context.Background(), "_443._tcp.example.com")
+	if err != nil {
+		t.Fatalf("lookupTLSA: %v", err)
+	}
+	want := "usage=3 selector=1 matching-type=1 cert=abcd"
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("lookupTLSA = %v, want [%q]", got, want)
+	}
+}
+
+func TestLookupSSHFP(t *testing.T) {
+	withFakeDNSQuery(t, func(ctx context.Context, domain, qtype string) ([]dns.RR, error) {
+		return []dns.RR{&dns.SSHFP{
+			Hdr:         dns.RR_Header{Name: domain, Rrtype: dns.TypeSSHFP},
+			Algorithm:   4,
+			Type:        2,
+			FingerPrint: "deadbeef",
+		}}, nil
+	})
+
+	got, err := lookupSSHFP(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("lookupSSHFP: %v", err)
+	}
+	want := "algorithm=4 type=2 fingerprint=deadbeef"
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("lookupSSHFP = %v, want [%q]", got, want)
+	}
+}
+
+func TestLookupNAPTR(t *testing.T) {
+	withFakeDNSQuery(t, func(ctx context.Context, domain, qtype string) ([]dns.RR, error) {
+		return []dns.RR{&dns.NAPTR{
+			Hdr:         dns.RR_Header{Name: domain, Rrtype: dns.TypeNAPTR},
+			Order:       100,
+			Preference:  10,
+			Flags:       "U",
+			Service:     "E2U+sip",
+			Regexp:      "!^.*$!sip:info@example.com!",
+			Replacement: ".",
+		}}, nil
+	})
+
+	got, err := lookupNAPTR(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("lookupNAPTR: %v", err)
+	}
+	want := `order=100 pref=10 flags="U" service="E2U+sip" regexp="!^.*$!sip:info@example.com!" replacement=.`
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("lookupNAPTR = %v, want [%q]", got, want)
+	}
+}
+
+func TestLookupHTTPS(t *testing.T) {
+	withFakeDNSQuery(t, func(ctx context.Context, domain, qtype string) ([]dns.RR, error) {
+		if qtype != "HTTPS" {
+			t.Fatalf("qtype = %q, want HTTPS", qtype)
+		}
+		return []dns.RR{&dns.HTTPS{SVCB: dns.SVCB{
+			Hdr:      dns.RR_Header{Name: domain, Rrtype: dns.TypeHTTPS},
+			Priority: 1,
+			Target:   "example.com.",
+		}}}, nil
+	})
+
+	got, err := lookupHTTPS(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("lookupHTTPS: %v", err)
+	}
+	want := "priority=1 target=example.com. "
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("lookupHTTPS = %v, want [%q]", got, want)
+	}
+}
+
+func TestLookupSVCB(t *testing.T) {
+	withFakeDNSQuery(t, func(ctx context.Context, domain, qtype string) ([]dns.RR, error) {
+		return []dns.RR{&dns.SVCB{
+			Hdr:      dns.RR_Header{Name: domain, Rrtype: dns.TypeSVCB},
+			Priority: 0,
+			Target:   ".",
+		}}, nil
+	})
+
+	got, err := lookupSVCB(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("lookupSVCB: %v", err)
+	}
+	want := "priority=0 target=. "
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("lookupSVCB = %v, want [%q]", got, want)
+	}
+}
+
+func TestLookupDNSKEYRecords(t *testing.T) {
+	withFakeDNSQuery(t, func(ctx context.Context, domain, qtype string) ([]dns.RR, error) {
+		return []dns.RR{&dns.DNSKEY{
+			Hdr:       dns.RR_Header{Name: domain, Rrtype: dns.TypeDNSKEY},
+			Flags:     256,
+			Protocol:  3,
+			Algorithm: dns.RSASHA256,
+			PublicKey: "AwEAAa==",
+		}}, nil
+	})
+
+	got, err := lookupDNSKEYRecords(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("lookupDNSKEYRecords: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("lookupDNSKEYRecords = %v, want 1 record", got)
+	}
+}
+
+func TestLookupDS(t *testing.T) {
+	withFakeDNSQuery(t, func(ctx context.Context, domain, qtype string) ([]dns.RR, error) {
+		return []dns.RR{&dns.DS{
+			Hdr:        dns.RR_Header{Name: domain, Rrtype: dns.TypeDS},
+			KeyTag:     12345,
+			Algorithm:  dns.RSASHA256,
+			DigestType: dns.SHA256,
+			Digest:     "abcdef0123456789",
+		}}, nil
+	})
+
+	got, err := lookupDS(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("lookupDS: %v", err)
+	}
+	want := "key-tag=12345 algorithm=8 digest-type=2 digest=abcdef0123456789"
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("lookupDS = %v, want [%q]", got, want)
+	}
+}
+
+func TestLookupModernRecordError(t *testing.T) {
+	withFakeDNSQuery(t, func(ctx context.Context, domain, qtype string) ([]dns.RR, error) {
+		return nil, context.DeadlineExceeded
+	})
+
+	if _, err := lookupCAA(context.Background(), "example.com"); err == nil {
+		t.Fatal("lookupCAA: expected error, got nil")
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Transport selects the wire protocol a Resolver uses to reach its
+// upstream servers.
+type Transport string
+
+const (
+	TransportUDP   Transport = "udp"
+	TransportTCP   Transport = "tcp"
+	TransportTLS   Transport = "tls"
+	TransportHTTPS Transport = "https"
+)
+
+// Resolver holds everything needed to send a query to one or more
+// upstream nameservers: the server list, the transport to use, a
+// per-query timeout, and a retry count. DoHURL is only consulted when
+// Transport is TransportHTTPS.
+type Resolver struct {
+	Servers   []string
+	Search    []string
+	Ndots     int
+	Transport Transport
+	DoHURL    string
+	Timeout   time.Duration
+	Retries   int
+}
+
+// resolvConfPath returns the platform-appropriate resolver config path.
+func resolvConfPath() string {
+	if runtime.GOOS == "windows" {
+		return os.Getenv("SystemRoot") + `\System32\drivers\etc\resolv.conf`
+	}
+	return "/etc/resolv.conf"
+}
+
+// DefaultResolver builds a Resolver from the system resolv.conf, falling
+// back to 8.8.8.8 over UDP if none can be read.
+func DefaultResolver() *Resolver {
+	r := &Resolver{
+		Transport: TransportUDP,
+		Timeout:   5 * time.Second,
+		Retries:   2,
+		Ndots:     1,
+	}
+	if err := r.loadResolvConf(resolvConfPath()); err != nil || len(r.Servers) == 0 {
+		r.Servers = []string{"8.8.8.8:53"}
+	}
+	return r
+}
+
+// loadResolvConf parses a resolv.conf-style file, populating Servers,
+// Search and Ndots.
+func (r *Resolver) loadResolvConf(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], ";") {
+			continue
+		}
+		switch fields[0] {
+		case "nameserver":
+			r.Servers = append(r.Servers, fields[1]+":53")
+		case "search", "domain":
+			r.Search = fields[1:]
+		case "options":
+			for _, opt := range fields[1:] {
+				if n, ok := strings.CutPrefix(opt, "ndots:"); ok {
+					if v, err := strconv.Atoi(n); err == nil {
+						r.Ndots = v
+					}
+				}
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// qualify expands name into the ordered list of fully-qualified candidate
+// names a resolver should try, honoring Search and Ndots the way
+// resolv.conf(5) describes: a name with at least Ndots dots is tried
+// absolute first and relative to Search as a fallback; a name with fewer
+// dots is tried relative to each Search domain first, falling back to
+// absolute. A trailing dot always means "absolute only".
+func (r *Resolver) qualify(name string) []string {
+	if strings.HasSuffix(name, ".") {
+		return []string{dns.Fqdn(name)}
+	}
+
+	absolute := dns.Fqdn(name)
+	if len(r.Search) == 0 {
+		return []string{absolute}
+	}
+
+	relative := make([]string, len(r.Search))
+	for i, domain := range r.Search {
+		relative[i] = dns.Fqdn(name + "." + strings.TrimSuffix(domain, "."))
+	}
+
+	if strings.Count(name, ".") >= r.Ndots {
+		return append([]string{absolute}, relative...)
+	}
+	return append(relative, absolute)
+}
+
+// Exchange sends m to the resolver's configured servers over its
+// configured transport, retrying on failure, and returns the response. It
+// honors ctx cancellation/deadline on every attempt.
+func (r *Resolver) Exchange(ctx context.Context, m *dns.Msg) (*dns.Msg, error) {
+	if r.Transport == TransportHTTPS {
+		return r.exchangeDoH(ctx, m)
+	}
+
+	c := &dns.Client{
+		Net:     dnsClientNet(r.Transport),
+		Timeout: r.Timeout,
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= r.Retries; attempt++ {
+		for _, server := range r.Servers {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			resp, _, err := c.ExchangeContext(ctx, m, server)
+			if err == nil {
+				return resp, nil
+			}
+			lastErr = err
+		}
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no upstream servers configured")
+	}
+	return nil, lastErr
+}
+
+func dnsClientNet(t Transport) string {
+	switch t {
+	case TransportTCP:
+		return "tcp"
+	case TransportTLS:
+		return "tcp-tls"
+	default:
+		return "udp"
+	}
+}
+
+// exchangeDoH implements RFC 8484: the packed query is POSTed as
+// application/dns-message and the response is unpacked the same way.
+func (r *Resolver) exchangeDoH(ctx context.Context, m *dns.Msg) (*dns.Msg, error) {
+	packed, err := m.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: r.Timeout}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.DoHURL, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("doh: unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	out := new(dns.Msg)
+	if err := out.Unpack(body); err != nil {
+		return nil, fmt.Errorf("doh: unpacking response: %w", err)
+	}
+	return out, nil
+}
+
+// globalResolver is the Resolver every lookup* helper routes through;
+// resolverFlags.apply reconfigures its fields in place once
+// --server/--tls/--doh-url/--timeout flags are parsed.
+var globalResolver = DefaultResolver()
+
+// resolverFlags holds the --server/--tls/--doh-url/--timeout flags shared
+// by every subcommand that talks to a resolver, so `godns srv` configures
+// globalResolver exactly the same way the default lookup path does.
+type resolverFlags struct {
+	server  *string
+	useTLS  *bool
+	dohURL  *string
+	timeout *time.Duration
+}
+
+// addResolverFlags registers the shared resolver flags on fs.
+func addResolverFlags(fs *flag.FlagSet) *resolverFlags {
+	return &resolverFlags{
+		server:  fs.String("server", "", "comma-separated upstream server(s) to query, overriding resolv.conf"),
+		useTLS:  fs.Bool("tls", false, "use DNS-over-TLS to reach --server"),
+		dohURL:  fs.String("doh-url", "", "use DNS-over-HTTPS, POSTing RFC 8484 queries to this URL"),
+		timeout: fs.Duration("timeout", 5*time.Second, "timeout for the whole run; per-query deadlines are derived from it"),
+	}
+}
+
+// apply reconfigures globalResolver from the parsed flag values.
+func (rf *resolverFlags) apply() {
+	globalResolver.Timeout = *rf.timeout
+	if *rf.server != "" {
+		var servers []string
+		for _, s := range strings.Split(*rf.server, ",") {
+			servers = append(servers, withDefaultPort(s, "53"))
+		}
+		globalResolver.Servers = servers
+	}
+	switch {
+	case *rf.dohURL != "":
+		globalResolver.Transport = TransportHTTPS
+		globalResolver.DoHURL = *rf.dohURL
+	case *rf.useTLS:
+		globalResolver.Transport = TransportTLS
+		if *rf.server != "" {
+			var servers []string
+			for _, s := range strings.Split(*rf.server, ",") {
+				servers = append(servers, withDefaultPort(s, "853"))
+			}
+			globalResolver.Servers = servers
+		}
+	}
+}
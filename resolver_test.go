@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolverQualify(t *testing.T) {
+	tests := []struct {
+		name   string
+		r      *Resolver
+		query  string
+		want   []string
+	}{
+		{
+			name:  "trailing dot is absolute only",
+			r:     &Resolver{Ndots: 1, Search: []string{"corp.example.com"}},
+			query: "host1.",
+			want:  []string{"host1."},
+		},
+		{
+			name:  "empty search list falls back to absolute",
+			r:     &Resolver{Ndots: 1},
+			query: "host1",
+			want:  []string{"host1."},
+		},
+		{
+			name:  "below ndots threshold tries search domains first",
+			r:     &Resolver{Ndots: 2, Search: []string{"corp.example.com", "example.net"}},
+			query: "host1",
+			want:  []string{"host1.corp.example.com.", "host1.example.net.", "host1."},
+		},
+		{
+			name:  "at or above ndots threshold tries absolute first",
+			r:     &Resolver{Ndots: 1, Search: []string{"corp.example.com"}},
+			query: "host1.svc",
+			want:  []string{"host1.svc.", "host1.svc.corp.example.com."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.r.qualify(tt.query)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("qualify(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadResolvConf(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "resolv.conf")
+	contents := "nameserver 10.0.0.1\nnameserver 10.0.0.2\nsearch corp.example.com example.net\noptions ndots:2 timeout:1\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	r := &Resolver{}
+	if err := r.loadResolvConf(path); err != nil {
+		t.Fatalf("loadResolvConf: %v", err)
+	}
+
+	wantServers := []string{"10.0.0.1:53", "10.0.0.2:53"}
+	if !reflect.DeepEqual(r.Servers, wantServers) {
+		t.Fatalf("Servers = %v, want %v", r.Servers, wantServers)
+	}
+	wantSearch := []string{"corp.example.com", "example.net"}
+	if !reflect.DeepEqual(r.Search, wantSearch) {
+		t.Fatalf("Search = %v, want %v", r.Search, wantSearch)
+	}
+	if r.Ndots != 2 {
+		t.Fatalf("Ndots = %d, want 2", r.Ndots)
+	}
+}
+
+func TestLoadResolvConfMissingFile(t *testing.T) {
+	r := &Resolver{}
+	if err := r.loadResolvConf(filepath.Join(t.TempDir(), "does-not-exist")); err == nil {
+		t.Fatal("loadResolvConf: expected error for missing file, got nil")
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// lookupSRV keeps the original free-form entry point used by the main
+// lookupFuncs table: it accepts a pre-formed name such as
+// "_xmpp-server._tcp.google.com" and queries it directly (the "back door"
+// form). For the service/proto/name triple, use lookupSRVFull instead.
+func lookupSRV(ctx context.Context, domain string) ([]string, error) {
+	_, srvs, err := lookupSRVFull(ctx, "", "", domain)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, srv := range srvs {
+		result = append(result, fmt.Sprintf("%s %d %d %d", srv.Target, srv.Port, srv.Priority, srv.Weight))
+	}
+	return result, nil
+}
+
+// lookupSRVFull mirrors net.LookupSRV's (cname string, addrs []*net.SRV, err
+// error) shape. If service and proto are both empty, name is treated as an
+// already-qualified SRV name (the back door); otherwise the standard
+// "_service._proto.name" query is built. The query name is left relative
+// (unless the caller rooted it with a trailing dot) so dnsQuery's
+// globalResolver.qualify can apply Search/Ndots, same as every other
+// lookup* helper.
+func lookupSRVFull(ctx context.Context, service, proto, name string) (string, []*dns.SRV, error) {
+	qname := srvQueryName(service, proto, name)
+	rrs, err := dnsQuery(ctx, qname, "SRV")
+	if err != nil {
+		return "", nil, err
+	}
+	var cname string
+	var srvs []*dns.SRV
+	for _, rr := range rrs {
+		switch v := rr.(type) {
+		case *dns.CNAME:
+			cname = v.Target
+		case *dns.SRV:
+			srvs = append(srvs, v)
+		}
+	}
+	if len(srvs) == 0 {
+		return "", nil, fmt.Errorf("no SRV records found for %s", qname)
+	}
+	return cname, srvs, nil
+}
+
+// srvQueryName builds the name to resolve, leaving it relative (as the
+// caller passed it) unless name is already rooted with a trailing dot; it
+// must not pre-qualify with dns.Fqdn, or qualify would see an absolute
+// name and skip search-domain/ndots expansion entirely.
+func srvQueryName(service, proto, name string) string {
+	if service == "" && proto == "" {
+		return name
+	}
+	return fmt.Sprintf("_%s._%s.%s", service, proto, name)
+}
+
+// wellKnownPorts is a small hard-coded stand-in for /etc/services, covering
+// the services this tool is commonly asked to resolve.
+var wellKnownPorts = map[string]map[string]int{
+	"tcp": {
+		"domain":      53,
+		"ftp":         21,
+		"http":        80,
+		"https":       443,
+		"smtp":        25,
+		"ssh":         22,
+		"xmpp-client": 5222,
+		"xmpp-server": 5269,
+	},
+	"udp": {
+		"domain": 53,
+		"ntp":    123,
+	},
+}
+
+// PortResolver resolves service names to port numbers, preferring any
+// user-supplied Overrides before falling back to the hard-coded
+// wellKnownPorts map.
+type PortResolver struct {
+	Overrides map[string]map[string]int
+}
+
+// LookupPort mirrors net.LookupPort(network, service) but also consults
+// Overrides first.
+func (p *PortResolver) LookupPort(network, service string) (int, error) {
+	if port, err := strconv.Atoi(service); err == nil {
+		return port, nil
+	}
+	if p != nil {
+		if port, ok := p.Overrides[network][service]; ok {
+			return port, nil
+		}
+	}
+	if port, ok := wellKnownPorts[network][service]; ok {
+		return port, nil
+	}
+	return 0, fmt.Errorf("unknown port for %s/%s", network, service)
+}
+
+// LookupPort resolves service names to port numbers using only the
+// hard-coded well-known port table; it mirrors net.LookupPort's signature.
+func LookupPort(network, service string) (int, error) {
+	return (&PortResolver{}).LookupPort(network, service)
+}
+
+// parsePortOverrides parses a comma-separated list of service=port pairs
+// (e.g. "http=8080,xmpp-client=52222") into the Overrides entry for a
+// single network, for --port-overrides.
+func parsePortOverrides(network, spec string) (map[string]map[string]int, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	overrides := map[string]map[string]int{network: {}}
+	for _, pair := range strings.Split(spec, ",") {
+		service, portStr, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --port-overrides entry %q, want service=port", pair)
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --port-overrides entry %q: %w", pair, err)
+		}
+		overrides[network][service] = port
+	}
+	return overrides, nil
+}
+
+// runSRVCommand implements `godns srv <service> <proto> <name>`. It uses
+// the same resolver flags (--server/--tls/--doh-url/--timeout) as the
+// main lookup path, plus --port-overrides for LookupPort.
+func runSRVCommand(args []string) {
+	fs := flag.NewFlagSet("godns srv", flag.ExitOnError)
+	rf := addResolverFlags(fs)
+	portOverrides := fs.String("port-overrides", "", "comma-separated service=port pairs, overriding the well-known port table for this lookup's proto")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: godns srv [--server host[,host...]] [--tls] [--doh-url url] [--timeout d] [--port-overrides service=port[,...]] <service> <proto> <name>\n")
+	}
+	fs.Parse(args)
+	if fs.NArg() != 3 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	service, proto, name := fs.Arg(0), fs.Arg(1), strings.TrimSuffix(fs.Arg(2), ".")
+
+	overrides, err := parsePortOverrides(proto, *portOverrides)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "srv: %v\n", err)
+		os.Exit(1)
+	}
+	ports := &PortResolver{Overrides: overrides}
+
+	rf.apply()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *rf.timeout)
+	defer cancel()
+
+	cname, srvs, err := lookupSRVFull(ctx, service, proto, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "srv: error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if cname != "" {
+		fmt.Printf("CNAME chain: %s\n", cname)
+	}
+	for _, srv := range srvs {
+		port, perr := ports.LookupPort(proto, service)
+		portDesc := fmt.Sprintf("%d", srv.Port)
+		if perr == nil && port != int(srv.Port) {
+			portDesc = fmt.Sprintf("%d (resolved %s/%s: %d)", srv.Port, proto, service, port)
+		}
+		fmt.Printf("target=%s port=%s priority=%d weight=%d\n", srv.Target, portDesc, srv.Priority, srv.Weight)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestSRVQueryName(t *testing.T) {
+	tests := []struct {
+		name             string
+		service, proto   string
+		target           string
+		want             string
+	}{
+		{"back door passes name through unchanged", "", "", "host1.corp.example.com.", "host1.corp.example.com."},
+		{"back door leaves a relative name relative", "", "", "host1", "host1"},
+		{"service/proto builds the standard prefix", "xmpp-server", "tcp", "google.com.", "_xmpp-server._tcp.google.com."},
+		{"service/proto leaves a relative name relative", "xmpp-server", "tcp", "host1", "_xmpp-server._tcp.host1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := srvQueryName(tt.service, tt.proto, tt.target)
+			if got != tt.want {
+				t.Fatalf("srvQueryName(%q, %q, %q) = %q, want %q", tt.service, tt.proto, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPortResolverLookupPort(t *testing.T) {
+	p := &PortResolver{Overrides: map[string]map[string]int{
+		"tcp": {"http": 8080},
+	}}
+
+	if port, err := p.LookupPort("tcp", "http"); err != nil || port != 8080 {
+		t.Fatalf("LookupPort(tcp, http) = %d, %v, want 8080, nil", port, err)
+	}
+	if port, err := p.LookupPort("tcp", "https"); err != nil || port != 443 {
+		t.Fatalf("LookupPort(tcp, https) = %d, %v, want 443, nil (fallback to well-known table)", port, err)
+	}
+	if port, err := p.LookupPort("tcp", "9999"); err != nil || port != 9999 {
+		t.Fatalf("LookupPort(tcp, 9999) = %d, %v, want 9999, nil (numeric service)", port, err)
+	}
+	if _, err := p.LookupPort("tcp", "no-such-service"); err == nil {
+		t.Fatal("LookupPort(tcp, no-such-service): expected error, got nil")
+	}
+}
+
+func TestParsePortOverrides(t *testing.T) {
+	got, err := parsePortOverrides("tcp", "http=8080,ssh=2222")
+	if err != nil {
+		t.Fatalf("parsePortOverrides: %v", err)
+	}
+	want := map[string]map[string]int{"tcp": {"http": 8080, "ssh": 2222}}
+	if len(got) != len(want) || got["tcp"]["http"] != 8080 || got["tcp"]["ssh"] != 2222 {
+		t.Fatalf("parsePortOverrides = %v, want %v", got, want)
+	}
+
+	if _, err := parsePortOverrides("tcp", "http:8080"); err == nil {
+		t.Fatal("parsePortOverrides: expected error for missing '=', got nil")
+	}
+	if _, err := parsePortOverrides("tcp", "http=notaport"); err == nil {
+		t.Fatal("parsePortOverrides: expected error for non-numeric port, got nil")
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// rootServers is the IANA root server priming list (A addresses of
+// a.root-servers.net through m.root-servers.net), embedded so --trace can
+// start iterative resolution without depending on the system resolver.
+var rootServers = []string{
+	"198.41.0.4",     // a.root-servers.net
+	"199.9.14.201",   // b.root-servers.net
+	"192.33.4.12",    // c.root-servers.net
+	"199.7.91.13",    // d.root-servers.net
+	"192.203.230.10", // e.root-servers.net
+	"192.5.5.241",    // f.root-servers.net
+	"192.112.36.4",   // g.root-servers.net
+	"198.97.190.53",  // h.root-servers.net
+	"192.36.148.17",  // i.root-servers.net
+	"192.58.128.30",  // j.root-servers.net
+	"193.0.14.129",   // k.root-servers.net
+	"199.7.83.42",    // l.root-servers.net
+	"202.12.27.33",   // m.root-servers.net
+}
+
+// TraceStep records one hop of iterative resolution: the server queried,
+// the delegation (if any) it returned, the glue used to reach the next
+// hop, and how long the query took.
+type TraceStep struct {
+	Zone       string
+	Server     string
+	RTT        time.Duration
+	Rcode      string
+	Delegation []string
+	Glue       []string
+}
+
+// delegationCache remembers the nameserver set returned for a zone so a
+// subsequent trace for a sibling name can skip straight past the TLD and
+// second-level referrals it already walked.
+var delegationCache = struct {
+	mu sync.Mutex
+	m  map[string][]string
+}{m: map[string][]string{}}
+
+func cachedDelegation(zone string) ([]string, bool) {
+	delegationCache.mu.Lock()
+	defer delegationCache.mu.Unlock()
+	servers, ok := delegationCache.m[zone]
+	return servers, ok
+}
+
+func cacheDelegation(zone string, servers []string) {
+	delegationCache.mu.Lock()
+	defer delegationCache.mu.Unlock()
+	delegationCache.m[zone] = servers
+}
+
+// startingServers returns the best cached delegation for name's ancestry,
+// falling back to the root servers.
+func startingServers(name string) (string, []string) {
+	labels := dns.SplitDomainName(name)
+	for i := 0; i < len(labels); i++ {
+		zone := dns.Fqdn(joinLabels(labels[i:]))
+		if servers, ok := cachedDelegation(zone); ok {
+			return zone, servers
+		}
+	}
+	return ".", rootServers
+}
+
+func joinLabels(labels []string) string {
+	out := ""
+	for i, l := range labels {
+		if i > 0 {
+			out += "."
+		}
+		out += l
+	}
+	return out
+}
+
+// iterativeResolve performs iterative resolution of name/qtype starting
+// from the IANA root servers (or a cached delegation, if a prior trace
+// already resolved an ancestor zone), following NS/glue referrals down to
+// an authoritative answer.
+func iterativeResolve(ctx context.Context, name, qtype string) (*dns.Msg, []TraceStep, error) {
+	fqdn := dns.Fqdn(name)
+	qtypeCode := dns.StringToType[qtype]
+	zone, servers := startingServers(fqdn)
+
+	var steps []TraceStep
+	const maxHops = 20
+
+	for hop := 0; hop < maxHops; hop++ {
+		msg, server, rtt, err := queryOneOf(ctx, servers, fqdn, qtypeCode)
+		step := TraceStep{Zone: zone, Server: server, RTT: rtt}
+		if err != nil {
+			steps = append(steps, step)
+			return nil, steps, fmt.Errorf("querying %s for %s: %w", server, zone, err)
+		}
+		step.Rcode = dns.RcodeToString[msg.Rcode]
+
+		if len(msg.Answer) > 0 || msg.Rcode == dns.RcodeNameError {
+			steps = append(steps, step)
+			return msg, steps, nil
+		}
+
+		nsNames, glue := delegationFrom(msg)
+		step.Delegation = nsNames
+		step.Glue = glue
+		steps = append(steps, step)
+
+		if len(nsNames) == 0 {
+			return msg, steps, fmt.Errorf("no answer or delegation from %s for %s", server, zone)
+		}
+
+		nextZone := delegationZone(msg, zone)
+		nextServers := glue
+		if len(nextServers) == 0 {
+			nextServers, err = resolveGlue(ctx, nsNames)
+			if err != nil || len(nextServers) == 0 {
+				return msg, steps, fmt.Errorf("could not resolve glue for delegation at %s: %w", nextZone, err)
+			}
+		}
+
+		cacheDelegation(nextZone, nextServers)
+		zone, servers = nextZone, nextServers
+	}
+
+	return nil, steps, fmt.Errorf("too many referrals resolving %s", fqdn)
+}
+
+// queryOneOf tries each server in turn (picking up after a timeout or
+// SERVFAIL) and returns the first usable response.
+func queryOneOf(ctx context.Context, servers []string, fqdn string, qtype uint16) (*dns.Msg, string, time.Duration, error) {
+	c := &dns.Client{Timeout: 3 * time.Second}
+	m := new(dns.Msg)
+	m.SetQuestion(fqdn, qtype)
+	m.RecursionDesired = false
+
+	var lastErr error
+	for _, server := range servers {
+		resp, rtt, err := c.ExchangeContext(ctx, m, withDefaultPort(server, "53"))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.Rcode == dns.RcodeServerFailure {
+			lastErr = fmt.Errorf("SERVFAIL from %s", server)
+			continue
+		}
+		return resp, server, rtt, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no servers to query")
+	}
+	return nil, "", 0, lastErr
+}
+
+// delegationFrom pulls NS names out of msg's authority section and any
+// A/AAAA glue for them out of the additional section.
+func delegationFrom(msg *dns.Msg) (nsNames, glue []string) {
+	for _, rr := range msg.Ns {
+		if ns, ok := rr.(*dns.NS); ok {
+			nsNames = append(nsNames, ns.Ns)
+		}
+	}
+	if len(nsNames) == 0 {
+		return nil, nil
+	}
+	wanted := make(map[string]bool, len(nsNames))
+	for _, ns := range nsNames {
+		wanted[ns] = true
+	}
+	for _, rr := range msg.Extra {
+		switch a := rr.(type) {
+		case *dns.A:
+			if wanted[a.Hdr.Name] {
+				glue = append(glue, a.A.String())
+			}
+		case *dns.AAAA:
+			if wanted[a.Hdr.Name] {
+				glue = append(glue, a.AAAA.String())
+			}
+		}
+	}
+	return nsNames, glue
+}
+
+// delegationZone returns the owner name of the NS records in msg's
+// authority section, i.e. the zone being delegated.
+func delegationZone(msg *dns.Msg, fallback string) string {
+	for _, rr := range msg.Ns {
+		if _, ok := rr.(*dns.NS); ok {
+			return rr.Header().Name
+		}
+	}
+	return fallback
+}
+
+// resolveGlue resolves nsNames to addresses when the delegating server
+// didn't provide glue, by recursively iterating each NS name's own A
+// record from the root (or from a cached delegation covering that name).
+func resolveGlue(ctx context.Context, nsNames []string) ([]string, error) {
+	var addrs []string
+	for _, ns := range nsNames {
+		msg, _, err := iterativeResolve(ctx, ns, "A")
+		if err != nil {
+			continue
+		}
+		for _, rr := range msg.Answer {
+			if a, ok := rr.(*dns.A); ok {
+				addrs = append(addrs, a.A.String())
+			}
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no nameserver in %v could be resolved", nsNames)
+	}
+	return addrs, nil
+}
+
+// runTrace implements `godns --trace <name>`, printing each hop of
+// iterative resolution followed by the final answer.
+func runTrace(ctx context.Context, name string) {
+	msg, steps, err := iterativeResolve(ctx, name, "A")
+	for _, step := range steps {
+		fmt.Printf("%-30s server=%-16s rtt=%-10s rcode=%s\n", step.Zone, step.Server, step.RTT, step.Rcode)
+		if len(step.Delegation) > 0 {
+			fmt.Printf("  delegation: %v\n", step.Delegation)
+		}
+		if len(step.Glue) > 0 {
+			fmt.Printf("  glue: %v\n", step.Glue)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "trace: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println()
+	for _, rr := range msg.Answer {
+		fmt.Println(rr.String())
+	}
+}